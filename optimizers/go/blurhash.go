@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+const base83Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// EncodeBlurHash produces the compact ASCII placeholder string popularized
+// by the BlurHash format, for use as a ~30-byte progressive-loading
+// placeholder. xComponents and yComponents must each be between 1 and 9.
+func EncodeBlurHash(data []byte, width, height int, xComponents, yComponents int) (string, error) {
+	if xComponents < 1 || xComponents > 9 || yComponents < 1 || yComponents > 9 {
+		return "", errors.New("blurhash: components must be between 1 and 9")
+	}
+	if width <= 0 || height <= 0 || len(data) < width*height*4 {
+		return "", errors.New("blurhash: invalid frame dimensions")
+	}
+
+	factors := make([][3]float64, xComponents*yComponents)
+	for cy := 0; cy < yComponents; cy++ {
+		for cx := 0; cx < xComponents; cx++ {
+			factors[cy*xComponents+cx] = blurHashBasis(data, width, height, cx, cy)
+		}
+	}
+
+	var sb strings.Builder
+
+	sizeFlag := (xComponents - 1) + (yComponents-1)*9
+	sb.WriteString(base83Encode(sizeFlag, 1))
+
+	dc := factors[0]
+	var maxAC float64
+	for i := 1; i < len(factors); i++ {
+		for c := 0; c < 3; c++ {
+			if v := math.Abs(factors[i][c]); v > maxAC {
+				maxAC = v
+			}
+		}
+	}
+
+	var quantMax int
+	if len(factors) > 1 {
+		quantMax = clampInt(int(math.Floor(maxAC*166-0.5)), 0, 82)
+		sb.WriteString(base83Encode(quantMax, 1))
+	} else {
+		sb.WriteString(base83Encode(0, 1))
+	}
+
+	sb.WriteString(base83Encode(encodeDC(dc), 4))
+
+	actualMaxAC := (float64(quantMax) + 1) / 166
+	for i := 1; i < len(factors); i++ {
+		sb.WriteString(base83Encode(encodeAC(factors[i], actualMaxAC), 2))
+	}
+
+	return sb.String(), nil
+}
+
+// blurHashBasis computes factor[cx][cy] = (scale/N) * sum basis(cx,x) *
+// basis(cy,y) * linearPixel(x,y), in linear light, for one DCT-II basis
+// function.
+func blurHashBasis(data []byte, width, height int, cx, cy int) [3]float64 {
+	var r, g, b float64
+	scale := 1.0
+	if cx > 0 || cy > 0 {
+		scale = 2.0
+	}
+
+	for y := 0; y < height; y++ {
+		basisY := math.Cos(math.Pi * float64(cy) * float64(y) / float64(height))
+		for x := 0; x < width; x++ {
+			basisX := math.Cos(math.Pi * float64(cx) * float64(x) / float64(width))
+			basis := basisX * basisY
+
+			idx := (y*width + x) * 4
+			r += basis * float64(srgbToLinear8[data[idx]]) / 255.0
+			g += basis * float64(srgbToLinear8[data[idx+1]]) / 255.0
+			b += basis * float64(srgbToLinear8[data[idx+2]]) / 255.0
+		}
+	}
+
+	n := float64(width * height)
+	return [3]float64{
+		scale / n * r,
+		scale / n * g,
+		scale / n * b,
+	}
+}
+
+// encodeDC packs the DC factor as a 24-bit sRGB triplet.
+func encodeDC(dc [3]float64) int {
+	r := linearToSrgb8[clampInt(int(dc[0]*255+0.5), 0, 255)]
+	g := linearToSrgb8[clampInt(int(dc[1]*255+0.5), 0, 255)]
+	b := linearToSrgb8[clampInt(int(dc[2]*255+0.5), 0, 255)]
+	return int(r)<<16 | int(g)<<8 | int(b)
+}
+
+// encodeAC quantizes an AC factor to three 0..18 values, combined as
+// q*19*19 + q*19 + q.
+func encodeAC(ac [3]float64, maxAC float64) int {
+	quant := func(v float64) int {
+		if maxAC == 0 {
+			return 9
+		}
+		sign := 1.0
+		if v < 0 {
+			sign = -1.0
+		}
+		q := math.Floor(sign*math.Pow(math.Abs(v)/maxAC, 0.5)*9 + 9.5)
+		return clampInt(int(q), 0, 18)
+	}
+	qr, qg, qb := quant(ac[0]), quant(ac[1]), quant(ac[2])
+	return qr*19*19 + qg*19 + qb
+}
+
+func base83Encode(value, length int) string {
+	out := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		digit := value % 83
+		out[i] = base83Alphabet[digit]
+		value /= 83
+	}
+	return string(out)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBase83Encode(t *testing.T) {
+	tests := []struct {
+		value, length int
+		want          string
+	}{
+		{0, 1, "0"},
+		{82, 1, "~"},
+		{83, 2, "10"},
+		{0, 4, "0000"},
+	}
+
+	for _, tt := range tests {
+		got := base83Encode(tt.value, tt.length)
+		if got != tt.want {
+			t.Errorf("base83Encode(%d, %d) = %q, want %q", tt.value, tt.length, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeACZeroMaxACQuantizesToMidpoint(t *testing.T) {
+	// maxAC == 0 means every AC factor in the frame was exactly zero (a flat
+	// color), so quant must not divide by zero; it should fall back to the
+	// 9 (middle of 0..18) per channel.
+	got := encodeAC([3]float64{0.3, -0.1, 0.05}, 0)
+	want := 9*19*19 + 9*19 + 9
+	if got != want {
+		t.Errorf("encodeAC with maxAC=0 = %d, want %d", got, want)
+	}
+}
+
+func TestEncodeACSignAndRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		ac         [3]float64
+		maxAC      float64
+		wantBelowQ bool // true if each channel's quant should land below 9 (negative)
+	}{
+		{"all negative", [3]float64{-0.5, -0.5, -0.5}, 1.0, true},
+		{"all positive", [3]float64{0.5, 0.5, 0.5}, 1.0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := encodeAC(tt.ac, tt.maxAC)
+			// Decompose back into the three base-19 digits the same way
+			// encodeAC packed them: q*19*19 + q*19 + q.
+			digits := [3]int{encoded / (19 * 19), (encoded / 19) % 19, encoded % 19}
+			for c, q := range digits {
+				below := q < 9
+				if below != tt.wantBelowQ {
+					t.Errorf("encodeAC(%v, %v) channel %d quant digit = %d, wantBelowQ=%v", tt.ac, tt.maxAC, c, q, tt.wantBelowQ)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeBlurHashInvalidInputs(t *testing.T) {
+	data := make([]byte, 4*4*4)
+
+	tests := []struct {
+		name                     string
+		width, height            int
+		xComponents, yComponents int
+	}{
+		{"too few x components", 4, 4, 0, 3},
+		{"too many x components", 4, 4, 10, 3},
+		{"too few y components", 4, 4, 3, 0},
+		{"too many y components", 4, 4, 3, 10},
+		{"zero width", 0, 4, 3, 3},
+		{"data too short", 4, 4, 3, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := data
+			if tt.name == "data too short" {
+				d = data[:4]
+			}
+			_, err := EncodeBlurHash(d, tt.width, tt.height, tt.xComponents, tt.yComponents)
+			if err == nil {
+				t.Errorf("EncodeBlurHash(%d, %d, %d, %d) expected error, got none", tt.width, tt.height, tt.xComponents, tt.yComponents)
+			}
+		})
+	}
+}
+
+func TestEncodeBlurHashSolidColor(t *testing.T) {
+	w, h := 4, 4
+	data := make([]byte, w*h*4)
+	for i := 0; i < w*h; i++ {
+		data[i*4] = 128
+		data[i*4+1] = 64
+		data[i*4+2] = 32
+		data[i*4+3] = 255
+	}
+
+	hash, err := EncodeBlurHash(data, w, h, 3, 3)
+	if err != nil {
+		t.Fatalf("EncodeBlurHash returned error: %v", err)
+	}
+	// sizeFlag(1) + maxAC(1) + DC(4) + 8 AC components(2 each) = 22 bytes.
+	wantLen := 1 + 1 + 4 + (3*3-1)*2
+	if len(hash) != wantLen {
+		t.Fatalf("EncodeBlurHash length = %d, want %d", len(hash), wantLen)
+	}
+	for _, c := range hash {
+		if !strings.ContainsRune(base83Alphabet, c) {
+			t.Errorf("EncodeBlurHash produced non-base83 character %q", c)
+		}
+	}
+}
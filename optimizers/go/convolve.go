@@ -0,0 +1,243 @@
+package main
+
+import (
+	"math"
+	"sync"
+)
+
+// Kernel is a 2D convolution kernel applied to RGBA frame data.
+type Kernel struct {
+	// Matrix is row-major, Rows*Cols entries.
+	Matrix     []float32
+	Rows, Cols int
+	// Divisor normalizes the weighted sum; Bias is added afterward.
+	Divisor float32
+	Bias    float32
+	// Alpha controls whether the alpha channel is convolved too, or passed
+	// through unchanged.
+	Alpha bool
+}
+
+// radiusX and radiusY return how many pixels the kernel reaches from center
+// on each axis, assuming odd dimensions.
+func (k Kernel) radiusX() int { return k.Cols / 2 }
+func (k Kernel) radiusY() int { return k.Rows / 2 }
+
+// Convolve applies kernel to data (RGBA8 bytes, width*height*4 long) using a
+// 4-way row-band goroutine split, with a radius-sized halo on each band so
+// results are deterministic across chunk boundaries.
+func Convolve(data []byte, width, height int, kernel Kernel) []byte {
+	output := make([]byte, len(data))
+	rx, ry := kernel.radiusX(), kernel.radiusY()
+
+	const workers = 4
+	rowsPerWorker := (height + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		startRow := w * rowsPerWorker
+		if startRow >= height {
+			break
+		}
+		endRow := startRow + rowsPerWorker
+		if endRow > height {
+			endRow = height
+		}
+
+		wg.Add(1)
+		go func(startRow, endRow int) {
+			defer wg.Done()
+			for y := startRow; y < endRow; y++ {
+				for x := 0; x < width; x++ {
+					convolvePixel(data, output, width, height, x, y, kernel, rx, ry)
+				}
+			}
+		}(startRow, endRow)
+	}
+
+	wg.Wait()
+	return output
+}
+
+func convolvePixel(data, output []byte, width, height, x, y int, kernel Kernel, rx, ry int) {
+	var sumR, sumG, sumB, sumA float32
+
+	for ky := 0; ky < kernel.Rows; ky++ {
+		sy := y + ky - ry
+		if sy < 0 {
+			sy = 0
+		} else if sy >= height {
+			sy = height - 1
+		}
+		for kx := 0; kx < kernel.Cols; kx++ {
+			sx := x + kx - rx
+			if sx < 0 {
+				sx = 0
+			} else if sx >= width {
+				sx = width - 1
+			}
+
+			idx := (sy*width + sx) * 4
+			weight := kernel.Matrix[ky*kernel.Cols+kx]
+			sumR += weight * float32(data[idx])
+			sumG += weight * float32(data[idx+1])
+			sumB += weight * float32(data[idx+2])
+			if kernel.Alpha {
+				sumA += weight * float32(data[idx+3])
+			}
+		}
+	}
+
+	divisor := kernel.Divisor
+	if divisor == 0 {
+		divisor = 1
+	}
+
+	outIdx := (y*width + x) * 4
+	output[outIdx] = clampf(sumR/divisor + kernel.Bias)
+	output[outIdx+1] = clampf(sumG/divisor + kernel.Bias)
+	output[outIdx+2] = clampf(sumB/divisor + kernel.Bias)
+	if kernel.Alpha {
+		output[outIdx+3] = clampf(sumA/divisor + kernel.Bias)
+	} else {
+		output[outIdx+3] = data[outIdx+3]
+	}
+}
+
+func clampf(v float32) byte {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return byte(v)
+}
+
+// BoxKernel returns a size x size averaging kernel (size must be odd).
+func BoxKernel(size int) Kernel {
+	matrix := make([]float32, size*size)
+	for i := range matrix {
+		matrix[i] = 1
+	}
+	return Kernel{Matrix: matrix, Rows: size, Cols: size, Divisor: float32(size * size)}
+}
+
+// GaussianKernel1D is the separable 1D form of a Gaussian kernel, so blur
+// can run as two O(n*k) passes instead of one O(n*k^2) pass.
+type GaussianKernel1D struct {
+	Weights []float32
+	Radius  int
+}
+
+// GaussianKernel builds a 2D Gaussian kernel for sigma, plus its separable
+// 1D form for callers that want the cheaper two-pass blur.
+func GaussianKernel(sigma float64) (Kernel, GaussianKernel1D) {
+	if sigma <= 0 {
+		sigma = 1
+	}
+	radius := int(math.Ceil(sigma * 3))
+	if radius < 1 {
+		radius = 1
+	}
+	size := radius*2 + 1
+
+	weights1D := make([]float32, size)
+	var sum float64
+	for i := 0; i < size; i++ {
+		x := float64(i - radius)
+		v := math.Exp(-(x * x) / (2 * sigma * sigma))
+		weights1D[i] = float32(v)
+		sum += v
+	}
+	for i := range weights1D {
+		weights1D[i] = float32(float64(weights1D[i]) / sum)
+	}
+
+	matrix := make([]float32, size*size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			matrix[y*size+x] = weights1D[x] * weights1D[y]
+		}
+	}
+
+	return Kernel{Matrix: matrix, Rows: size, Cols: size, Divisor: 1},
+		GaussianKernel1D{Weights: weights1D, Radius: radius}
+}
+
+// ConvolveSeparable runs a separable 1D kernel as a horizontal pass followed
+// by a vertical pass, which is O(n*k) rather than the O(n*k^2) of the full
+// 2D form.
+func ConvolveSeparable(data []byte, width, height int, k1d GaussianKernel1D) []byte {
+	horizontal := Kernel{Matrix: k1d.Weights, Rows: 1, Cols: len(k1d.Weights), Divisor: 1}
+	pass1 := Convolve(data, width, height, horizontal)
+
+	vertical := Kernel{Matrix: k1d.Weights, Rows: len(k1d.Weights), Cols: 1, Divisor: 1}
+	return Convolve(pass1, width, height, vertical)
+}
+
+// Sharpen returns an unsharp-mask kernel: the original plus amount times
+// (original minus a Gaussian blur of the given radius).
+func Sharpen(amount, radius float64) Kernel {
+	blur, _ := GaussianKernel(radius)
+	size := blur.Rows
+	center := size / 2
+
+	matrix := make([]float32, size*size)
+	for i, w := range blur.Matrix {
+		matrix[i] = -float32(amount) * w
+	}
+	matrix[center*size+center] += float32(1 + amount)
+
+	return Kernel{Matrix: matrix, Rows: size, Cols: size, Divisor: 1}
+}
+
+// SobelX and SobelY are the standard 3x3 Sobel edge-detection kernels.
+var SobelX = Kernel{
+	Matrix:  []float32{-1, 0, 1, -2, 0, 2, -1, 0, 1},
+	Rows:    3,
+	Cols:    3,
+	Divisor: 1,
+	Bias:    128,
+}
+
+var SobelY = Kernel{
+	Matrix:  []float32{-1, -2, -1, 0, 0, 0, 1, 2, 1},
+	Rows:    3,
+	Cols:    3,
+	Divisor: 1,
+	Bias:    128,
+}
+
+// EmbossKernel is a standard 3x3 emboss kernel with a mid-gray bias.
+var EmbossKernel = Kernel{
+	Matrix:  []float32{-2, -1, 0, -1, 1, 1, 0, 1, 2},
+	Rows:    3,
+	Cols:    3,
+	Divisor: 1,
+	Bias:    128,
+}
+
+// SobelEdges combines SobelX and SobelY via magnitude to produce a
+// grayscale edge map, written into all three color channels.
+func SobelEdges(data []byte, width, height int) []byte {
+	gx := Convolve(data, width, height, SobelX)
+	gy := Convolve(data, width, height, SobelY)
+
+	output := make([]byte, len(data))
+	for i := 0; i+3 < len(data); i += 4 {
+		mag := 0.0
+		for c := 0; c < 3; c++ {
+			dx := float64(gx[i+c]) - 128
+			dy := float64(gy[i+c]) - 128
+			mag += dx*dx + dy*dy
+		}
+		mag = math.Sqrt(mag / 3)
+		v := clampf(float32(mag))
+		output[i] = v
+		output[i+1] = v
+		output[i+2] = v
+		output[i+3] = data[i+3]
+	}
+	return output
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"image/color"
+	"sort"
+)
+
+// Ditherer quantizes a pixel and diffuses the resulting error to its
+// neighbors. Implementations decide both the quantization levels and the
+// error kernel, so ApplyDithering can stay a thin driver loop.
+type Ditherer interface {
+	// Quantize reduces pixel to the ditherer's color levels.
+	Quantize(pixel color.RGBA) color.RGBA
+	// Diffuse spreads the per-channel quantization error (err) from (x, y)
+	// into buf, which holds the same RGBA byte layout as OptimizeFrame.
+	Diffuse(err [3]int, x, y int, buf []byte, width, height int)
+}
+
+// diffuseKernel is a (dx, dy, numerator) entry of an error-diffusion matrix,
+// applied as err*numerator/divisor.
+type diffuseKernel struct {
+	dx, dy, num int
+}
+
+func diffuse(kernel []diffuseKernel, divisor int, err [3]int, x, y int, buf []byte, width, height int) {
+	for _, k := range kernel {
+		nx, ny := x+k.dx, y+k.dy
+		if nx < 0 || nx >= width || ny < 0 || ny >= height {
+			continue
+		}
+		idx := (ny*width + nx) * 4
+		if idx+2 >= len(buf) {
+			continue
+		}
+		buf[idx] = clamp(int(buf[idx]) + err[0]*k.num/divisor)
+		buf[idx+1] = clamp(int(buf[idx+1]) + err[1]*k.num/divisor)
+		buf[idx+2] = clamp(int(buf[idx+2]) + err[2]*k.num/divisor)
+	}
+}
+
+// uniformQuantize truncates each channel down to a multiple of step; at the
+// default step of 32 that gives 8 levels, matching the original per-channel
+// bucketing.
+func uniformQuantize(pixel color.RGBA, step int) color.RGBA {
+	return color.RGBA{
+		R: byte((int(pixel.R) / step) * step),
+		G: byte((int(pixel.G) / step) * step),
+		B: byte((int(pixel.B) / step) * step),
+		A: pixel.A,
+	}
+}
+
+// FloydSteinbergDitherer is the classic 7/16, 3/16, 5/16, 1/16 kernel.
+type FloydSteinbergDitherer struct{ Step int }
+
+func (d FloydSteinbergDitherer) Quantize(pixel color.RGBA) color.RGBA {
+	return uniformQuantize(pixel, d.step())
+}
+
+func (d FloydSteinbergDitherer) Diffuse(err [3]int, x, y int, buf []byte, width, height int) {
+	diffuse([]diffuseKernel{
+		{1, 0, 7}, {-1, 1, 3}, {0, 1, 5}, {1, 1, 1},
+	}, 16, err, x, y, buf, width, height)
+}
+
+func (d FloydSteinbergDitherer) step() int {
+	if d.Step <= 0 {
+		return 32
+	}
+	return d.Step
+}
+
+// AtkinsonDitherer spreads only 6/8 of the error across six neighbors,
+// discarding 2/8 for the characteristic lighter, higher-contrast look.
+type AtkinsonDitherer struct{ Step int }
+
+func (d AtkinsonDitherer) Quantize(pixel color.RGBA) color.RGBA {
+	return uniformQuantize(pixel, d.step())
+}
+
+func (d AtkinsonDitherer) Diffuse(err [3]int, x, y int, buf []byte, width, height int) {
+	diffuse([]diffuseKernel{
+		{1, 0, 1}, {2, 0, 1},
+		{-1, 1, 1}, {0, 1, 1}, {1, 1, 1},
+		{0, 2, 1},
+	}, 8, err, x, y, buf, width, height)
+}
+
+func (d AtkinsonDitherer) step() int {
+	if d.Step <= 0 {
+		return 32
+	}
+	return d.Step
+}
+
+// Sierra3Ditherer is the 3-row, 5-column Sierra kernel with divisor 32.
+type Sierra3Ditherer struct{ Step int }
+
+func (d Sierra3Ditherer) Quantize(pixel color.RGBA) color.RGBA {
+	return uniformQuantize(pixel, d.step())
+}
+
+func (d Sierra3Ditherer) Diffuse(err [3]int, x, y int, buf []byte, width, height int) {
+	diffuse([]diffuseKernel{
+		{1, 0, 5}, {2, 0, 3},
+		{-2, 1, 2}, {-1, 1, 4}, {0, 1, 5}, {1, 1, 4}, {2, 1, 2},
+		{-1, 2, 2}, {0, 2, 3}, {1, 2, 2},
+	}, 32, err, x, y, buf, width, height)
+}
+
+func (d Sierra3Ditherer) step() int {
+	if d.Step <= 0 {
+		return 32
+	}
+	return d.Step
+}
+
+// JarvisJudiceNinkeDitherer is the 12-neighbor, divisor-48 kernel.
+type JarvisJudiceNinkeDitherer struct{ Step int }
+
+func (d JarvisJudiceNinkeDitherer) Quantize(pixel color.RGBA) color.RGBA {
+	return uniformQuantize(pixel, d.step())
+}
+
+func (d JarvisJudiceNinkeDitherer) Diffuse(err [3]int, x, y int, buf []byte, width, height int) {
+	diffuse([]diffuseKernel{
+		{1, 0, 7}, {2, 0, 5},
+		{-2, 1, 3}, {-1, 1, 5}, {0, 1, 7}, {1, 1, 5}, {2, 1, 3},
+		{-2, 2, 1}, {-1, 2, 3}, {0, 2, 5}, {1, 2, 3}, {2, 2, 1},
+	}, 48, err, x, y, buf, width, height)
+}
+
+func (d JarvisJudiceNinkeDitherer) step() int {
+	if d.Step <= 0 {
+		return 32
+	}
+	return d.Step
+}
+
+// bayer4x4 and bayer8x8 are the standard ordered-dither index matrices
+// (0..15 and 0..63). BayerDitherer.threshold scales them on each call to a
+// bias centred on zero: -128..112 for 4x4 and -128..124 for 8x8.
+var bayer4x4 = [4][4]int{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+var bayer8x8 = [8][8]int{
+	{0, 48, 12, 60, 3, 51, 15, 63},
+	{32, 16, 44, 28, 35, 19, 47, 31},
+	{8, 56, 4, 52, 11, 59, 7, 55},
+	{40, 24, 36, 20, 43, 27, 39, 23},
+	{2, 50, 14, 62, 1, 49, 13, 61},
+	{34, 18, 46, 30, 33, 17, 45, 29},
+	{10, 58, 6, 54, 9, 57, 5, 53},
+	{42, 26, 38, 22, 41, 25, 37, 21},
+}
+
+// BayerDitherer is ordered dithering against a precomputed threshold matrix;
+// it performs no error diffusion, so Diffuse is a no-op.
+type BayerDitherer struct {
+	// Size is 4 or 8, selecting the 4x4 or 8x8 matrix. Defaults to 8.
+	Size int
+	Step int
+}
+
+func (d BayerDitherer) step() int {
+	if d.Step <= 0 {
+		return 32
+	}
+	return d.Step
+}
+
+func (d BayerDitherer) threshold(x, y int) int {
+	if d.Size == 4 {
+		n := bayer4x4[y%4][x%4]
+		return (n*256)/16 - 128
+	}
+	n := bayer8x8[y%8][x%8]
+	return (n*256)/64 - 128
+}
+
+// QuantizeAt applies the position-dependent ordered threshold, since plain
+// Quantize cannot see (x, y).
+func (d BayerDitherer) QuantizeAt(pixel color.RGBA, x, y int) color.RGBA {
+	step := d.step()
+	bias := d.threshold(x, y) * step / 256
+	return color.RGBA{
+		R: byte((clampInt(int(pixel.R)+bias, 0, 255) / step) * step),
+		G: byte((clampInt(int(pixel.G)+bias, 0, 255) / step) * step),
+		B: byte((clampInt(int(pixel.B)+bias, 0, 255) / step) * step),
+		A: pixel.A,
+	}
+}
+
+func (d BayerDitherer) Quantize(pixel color.RGBA) color.RGBA {
+	return uniformQuantize(pixel, d.step())
+}
+
+func (d BayerDitherer) Diffuse(err [3]int, x, y int, buf []byte, width, height int) {
+	// Ordered dithering carries no error between pixels.
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// paletteNode is a node in the palette k-d tree. Nodes split on R, G and B
+// in turn, by depth.
+type paletteNode struct {
+	color       color.RGBA
+	index       int
+	axis        int
+	left, right *paletteNode
+}
+
+// PaletteDitherer dithers to a fixed color.Palette (e.g. a GIF or web-safe
+// palette) using nearest-color search accelerated by a 3D k-d tree.
+type PaletteDitherer struct {
+	Palette color.Palette
+	root    *paletteNode
+}
+
+// NewPaletteDitherer builds the k-d tree once so repeated Quantize calls
+// avoid a linear scan over large palettes.
+func NewPaletteDitherer(palette color.Palette) *PaletteDitherer {
+	entries := make([]paletteNode, len(palette))
+	for i, c := range palette {
+		r, g, b, a := c.RGBA()
+		entries[i] = paletteNode{
+			color: color.RGBA{R: byte(r >> 8), G: byte(g >> 8), B: byte(b >> 8), A: byte(a >> 8)},
+			index: i,
+		}
+	}
+	pd := &PaletteDitherer{Palette: palette}
+	pd.root = buildPaletteTree(entries, 0)
+	return pd
+}
+
+func buildPaletteTree(nodes []paletteNode, depth int) *paletteNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	axis := depth % 3
+	sort.Slice(nodes, func(i, j int) bool {
+		return channelOf(nodes[i].color, axis) < channelOf(nodes[j].color, axis)
+	})
+	mid := len(nodes) / 2
+	node := nodes[mid]
+	node.axis = axis
+	node.left = buildPaletteTree(nodes[:mid], depth+1)
+	node.right = buildPaletteTree(nodes[mid+1:], depth+1)
+	return &node
+}
+
+func channelOf(c color.RGBA, axis int) int {
+	switch axis {
+	case 0:
+		return int(c.R)
+	case 1:
+		return int(c.G)
+	default:
+		return int(c.B)
+	}
+}
+
+func sqDist(a, b color.RGBA) int {
+	dr := int(a.R) - int(b.R)
+	dg := int(a.G) - int(b.G)
+	db := int(a.B) - int(b.B)
+	return dr*dr + dg*dg + db*db
+}
+
+func (pd *PaletteDitherer) nearest(target color.RGBA) color.RGBA {
+	best := pd.root.color
+	bestDist := sqDist(target, best)
+	var search func(n *paletteNode)
+	search = func(n *paletteNode) {
+		if n == nil {
+			return
+		}
+		if dist := sqDist(target, n.color); dist < bestDist {
+			bestDist = dist
+			best = n.color
+		}
+
+		diff := channelOf(target, n.axis) - channelOf(n.color, n.axis)
+		near, far := n.left, n.right
+		if diff > 0 {
+			near, far = n.right, n.left
+		}
+		search(near)
+		if diff*diff < bestDist {
+			search(far)
+		}
+	}
+	search(pd.root)
+	return best
+}
+
+func (pd *PaletteDitherer) Quantize(pixel color.RGBA) color.RGBA {
+	if pd.root == nil {
+		return pixel
+	}
+	nearest := pd.nearest(pixel)
+	nearest.A = pixel.A
+	return nearest
+}
+
+func (pd *PaletteDitherer) Diffuse(err [3]int, x, y int, buf []byte, width, height int) {
+	diffuse([]diffuseKernel{
+		{1, 0, 7}, {-1, 1, 3}, {0, 1, 5}, {1, 1, 1},
+	}, 16, err, x, y, buf, width, height)
+}
+
+// ApplyDitherer runs any Ditherer over the frame, diffusing error as it
+// goes, and covers the full frame including x==0, x==width-1 and
+// y==height-1, which the original hardcoded ApplyDithering skipped. It
+// replaces the old hardcoded Floyd-Steinberg-only ApplyDithering and runs
+// through TileProcessor.ApplyDithererTiled with 256x256 tiles. That pass
+// works on a full-frame copy of data, so the whole frame is held twice;
+// the tiles set the order in which pixels are visited, not how much
+// memory is used.
+func ApplyDitherer(data []byte, width, height int, d Ditherer) []byte {
+	return NewTileProcessor(256, 256, 0).ApplyDithererTiled(data, width, height, d)
+}
+
+// ApplyDithering applies Floyd-Steinberg dithering for better color depth.
+// Kept for existing callers; new code should use ApplyDitherer directly.
+func ApplyDithering(data []byte, width, height int) []byte {
+	return ApplyDitherer(data, width, height, FloydSteinbergDitherer{})
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// NormalizeOrientation rotates/flips an RGBA byte buffer so that an EXIF
+// Orientation value of 1 (identity) always holds afterward. It supports all
+// eight EXIF orientation values and operates on the same RGBA layout used
+// by OptimizeFrame. orientation values outside 1-8 (including 0, meaning
+// "no EXIF tag found") are treated as identity.
+func NormalizeOrientation(data []byte, width, height int, orientation int) (out []byte, newW, newH int) {
+	switch orientation {
+	case 2: // flip horizontal
+		return flipH(data, width, height), width, height
+	case 3: // rotate 180
+		return rotate180(data, width, height), width, height
+	case 4: // flip vertical
+		return flipV(data, width, height), width, height
+	case 5: // transpose (flip along top-left/bottom-right diagonal)
+		return transpose(data, width, height), height, width
+	case 6: // rotate 90 CW
+		return rotate90CW(data, width, height), height, width
+	case 7: // transverse (flip along top-right/bottom-left diagonal)
+		return transverse(data, width, height), height, width
+	case 8: // rotate 90 CCW
+		return rotate90CCW(data, width, height), height, width
+	default: // 1, 0, or unrecognized: identity
+		out = make([]byte, len(data))
+		copy(out, data)
+		return out, width, height
+	}
+}
+
+func pixelAt(data []byte, width int, x, y int) []byte {
+	idx := (y*width + x) * 4
+	return data[idx : idx+4]
+}
+
+func setPixelAt(out []byte, width int, x, y int, px []byte) {
+	idx := (y*width + x) * 4
+	copy(out[idx:idx+4], px)
+}
+
+func flipH(data []byte, width, height int) []byte {
+	out := make([]byte, len(data))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			setPixelAt(out, width, width-1-x, y, pixelAt(data, width, x, y))
+		}
+	}
+	return out
+}
+
+func flipV(data []byte, width, height int) []byte {
+	out := make([]byte, len(data))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			setPixelAt(out, width, x, height-1-y, pixelAt(data, width, x, y))
+		}
+	}
+	return out
+}
+
+func rotate180(data []byte, width, height int) []byte {
+	out := make([]byte, len(data))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			setPixelAt(out, width, width-1-x, height-1-y, pixelAt(data, width, x, y))
+		}
+	}
+	return out
+}
+
+// transpose flips across the main diagonal: output is height x width.
+func transpose(data []byte, width, height int) []byte {
+	out := make([]byte, len(data))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			setPixelAt(out, height, y, x, pixelAt(data, width, x, y))
+		}
+	}
+	return out
+}
+
+// transverse flips across the anti-diagonal: output is height x width.
+func transverse(data []byte, width, height int) []byte {
+	out := make([]byte, len(data))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			setPixelAt(out, height, height-1-y, width-1-x, pixelAt(data, width, x, y))
+		}
+	}
+	return out
+}
+
+// rotate90CW rotates clockwise: output is height x width.
+func rotate90CW(data []byte, width, height int) []byte {
+	out := make([]byte, len(data))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			setPixelAt(out, height, height-1-y, x, pixelAt(data, width, x, y))
+		}
+	}
+	return out
+}
+
+// rotate90CCW rotates counter-clockwise: output is height x width.
+func rotate90CCW(data []byte, width, height int) []byte {
+	out := make([]byte, len(data))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			setPixelAt(out, height, y, width-1-x, pixelAt(data, width, x, y))
+		}
+	}
+	return out
+}
+
+var errNoEXIFOrientation = errors.New("exif: no orientation tag found")
+
+// DecodeEXIFOrientation walks a JPEG's APP1/TIFF IFD0 looking for tag
+// 0x0112 (Orientation), without pulling in a full EXIF decoding dependency.
+// It returns errNoEXIFOrientation if the file has no APP1/EXIF segment or
+// the segment has no orientation tag.
+func DecodeEXIFOrientation(jpeg []byte) (int, error) {
+	if len(jpeg) < 4 || jpeg[0] != 0xFF || jpeg[1] != 0xD8 {
+		return 0, errors.New("exif: not a JPEG")
+	}
+
+	pos := 2
+	for pos+4 <= len(jpeg) {
+		if jpeg[pos] != 0xFF {
+			return 0, errNoEXIFOrientation
+		}
+		marker := jpeg[pos+1]
+		if marker == 0xD8 || marker == 0xD9 {
+			pos += 2
+			continue
+		}
+		segLen := int(binary.BigEndian.Uint16(jpeg[pos+2 : pos+4]))
+		segStart := pos + 4
+		segEnd := pos + 2 + segLen
+		if segEnd > len(jpeg) {
+			break
+		}
+
+		if marker == 0xE1 { // APP1
+			if o, err := parseEXIFApp1(jpeg[segStart:segEnd]); err == nil {
+				return o, nil
+			}
+		}
+		if marker == 0xDA { // start of scan: no more metadata follows
+			break
+		}
+
+		pos = segEnd
+	}
+
+	return 0, errNoEXIFOrientation
+}
+
+func parseEXIFApp1(seg []byte) (int, error) {
+	if len(seg) < 10 || string(seg[0:6]) != "Exif\x00\x00" {
+		return 0, errNoEXIFOrientation
+	}
+	tiff := seg[6:]
+	if len(tiff) < 8 {
+		return 0, errNoEXIFOrientation
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0, errNoEXIFOrientation
+	}
+
+	ifd0Offset := order.Uint32(tiff[4:8])
+	if int(ifd0Offset)+2 > len(tiff) {
+		return 0, errNoEXIFOrientation
+	}
+
+	entryCount := int(order.Uint16(tiff[ifd0Offset : ifd0Offset+2]))
+	entriesStart := int(ifd0Offset) + 2
+
+	for i := 0; i < entryCount; i++ {
+		entryOffset := entriesStart + i*12
+		if entryOffset+12 > len(tiff) {
+			break
+		}
+		tag := order.Uint16(tiff[entryOffset : entryOffset+2])
+		if tag == 0x0112 {
+			valueType := order.Uint16(tiff[entryOffset+2 : entryOffset+4])
+			if valueType != 3 { // SHORT
+				return 0, errNoEXIFOrientation
+			}
+			return int(order.Uint16(tiff[entryOffset+8 : entryOffset+10])), nil
+		}
+	}
+
+	return 0, errNoEXIFOrientation
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// buildExifJPEG constructs a minimal JPEG with a single-tag APP1/EXIF IFD0
+// carrying the Orientation tag, in the given TIFF byte order.
+func buildExifJPEG(order binary.ByteOrder, byteOrderMarker string, orientation uint16) []byte {
+	tiff := make([]byte, 8)
+	copy(tiff[0:2], byteOrderMarker)
+	order.PutUint16(tiff[2:4], 0x002A)
+	order.PutUint32(tiff[4:8], 8)
+
+	ifd := make([]byte, 2+12)
+	order.PutUint16(ifd[0:2], 1)
+	entry := ifd[2:14]
+	order.PutUint16(entry[0:2], 0x0112)
+	order.PutUint16(entry[2:4], 3)
+	order.PutUint32(entry[4:8], 1)
+	order.PutUint16(entry[8:10], orientation)
+
+	tiff = append(tiff, ifd...)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+
+	segLen := make([]byte, 2)
+	binary.BigEndian.PutUint16(segLen, uint16(2+len(payload)))
+
+	jpeg := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	jpeg = append(jpeg, segLen...)
+	jpeg = append(jpeg, payload...)
+	jpeg = append(jpeg, 0xFF, 0xD9)
+	return jpeg
+}
+
+func TestDecodeEXIFOrientation(t *testing.T) {
+	tests := []struct {
+		name    string
+		jpeg    []byte
+		want    int
+		wantErr bool
+	}{
+		{"little-endian orientation 6", buildExifJPEG(binary.LittleEndian, "II", 6), 6, false},
+		{"big-endian orientation 3", buildExifJPEG(binary.BigEndian, "MM", 3), 3, false},
+		{"no APP1 segment", []byte{0xFF, 0xD8, 0xFF, 0xD9}, 0, true},
+		{"not a JPEG", []byte{0x00, 0x01, 0x02, 0x03}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeEXIFOrientation(tt.jpeg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeEXIFOrientation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("DecodeEXIFOrientation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeOrientationDimensions(t *testing.T) {
+	// 2x1 RGBA image: pixel A then pixel B.
+	data := []byte{
+		1, 1, 1, 255,
+		2, 2, 2, 255,
+	}
+
+	tests := []struct {
+		name            string
+		orientation     int
+		wantW, wantH    int
+		wantFirstPixelR byte
+	}{
+		{"identity (0)", 0, 2, 1, 1},
+		{"identity (1)", 1, 2, 1, 1},
+		{"flip horizontal (2)", 2, 2, 1, 2},
+		{"rotate 180 (3)", 3, 2, 1, 2},
+		{"rotate 90 CW (6) swaps dimensions", 6, 1, 2, 1},
+		{"rotate 90 CCW (8) swaps dimensions", 8, 1, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, w, h := NormalizeOrientation(data, 2, 1, tt.orientation)
+			if w != tt.wantW || h != tt.wantH {
+				t.Fatalf("dimensions = (%d, %d), want (%d, %d)", w, h, tt.wantW, tt.wantH)
+			}
+			if out[0] != tt.wantFirstPixelR {
+				t.Errorf("first pixel R = %d, want %d", out[0], tt.wantFirstPixelR)
+			}
+		})
+	}
+}
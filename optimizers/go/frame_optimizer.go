@@ -1,119 +1,114 @@
 package main
 
-import (
-	"sync"
+import "math"
+
+// GammaMode selects how OptimizeFrame maps color channel values.
+type GammaMode int
+
+const (
+	// GammaSRGB applies the real piecewise sRGB transfer function (via
+	// linearToSrgb8), encoding a linear-light input value into the
+	// display-ready sRGB byte it should have been all along. This replaces
+	// the old v*1.8 approximation as the default.
+	GammaSRGB GammaMode = iota
+	// GammaPure22 applies a pure gamma-2.2 power curve in the same
+	// direction as GammaSRGB, for callers that want the simpler curve
+	// shape instead of the piecewise sRGB one.
+	GammaPure22
+	// GammaLinear applies the old v*1.8 linear approximation, kept for
+	// callers that relied on the previous (incorrect) behavior.
+	GammaLinear
+	// GammaNone leaves color values untouched.
+	GammaNone
+)
+
+// FrameOptions configures OptimizeFrame. The zero value applies sRGB gamma
+// correction and no orientation normalization; the old v*1.8 curve is
+// available as GammaLinear.
+type FrameOptions struct {
+	Gamma GammaMode
+	// Orientation is an EXIF orientation value (1-8). 0 and 1 are both
+	// treated as identity, so callers can pass a JPEG's raw tag value
+	// unconditionally.
+	Orientation int
+}
+
+// srgbToLinear8 and linearToSrgb8 are 256-entry lookup tables so the
+// per-pixel path in OptimizeFrame is a table lookup rather than a pow() call.
+var (
+	srgbToLinear8 [256]uint8
+	linearToSrgb8 [256]uint8
 )
 
-// OptimizeFrame processes frame data in parallel using goroutines
-// Optimized for WebAssembly execution
-func OptimizeFrame(data []byte, width, height int) []byte {
-	optimized := make([]byte, len(data))
-	chunkSize := len(data) / 4
-	var wg sync.WaitGroup
-	
-	// Process in 4 parallel chunks
-	for i := 0; i < 4; i++ {
-		wg.Add(1)
-		go func(start int) {
-			defer wg.Done()
-			end := start + chunkSize
-			if end > len(data) {
-				end = len(data)
-			}
-			
-			// Apply optimizations to chunk
-			for j := start; j < end; j += 4 {
-				if j+3 >= len(data) {
-					break
-				}
-				
-				// RGBA values
-				r, g, b, a := data[j], data[j+1], data[j+2], data[j+3]
-				
-				// Fast gamma correction (2.2 gamma)
-				optimized[j] = gammaCorrect(r)
-				optimized[j+1] = gammaCorrect(g)
-				optimized[j+2] = gammaCorrect(b)
-				optimized[j+3] = a // Alpha unchanged
-			}
-		}(i * chunkSize)
+func init() {
+	for i := 0; i < 256; i++ {
+		v := float64(i) / 255.0
+		srgbToLinear8[i] = to255(srgbToLinear(v))
+		linearToSrgb8[i] = to255(linearToSrgb(v))
+	}
+}
+
+// srgbToLinear converts a normalized sRGB channel value to linear light.
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSrgb converts a normalized linear channel value to sRGB-encoded.
+func linearToSrgb(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
 	}
-	
-	wg.Wait()
-	return optimized
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
 }
 
-// gammaCorrect applies fast gamma 2.2 correction
-func gammaCorrect(v byte) byte {
-	// Fast approximation: v^2.2 ≈ v * 1.8 (linear approximation)
-	val := float64(v) * 1.8
-	if val > 255 {
+func to255(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
 		return 255
 	}
-	return byte(val)
+	return uint8(v*255.0 + 0.5)
+}
+
+// OptimizeFrame processes frame data tile-by-tile via TileProcessor. The
+// gamma pass writes its result back into the frame buffer and allocates no
+// second full-frame buffer; without orientation normalization the returned
+// slice is data itself. When opts.Orientation encodes a non-identity EXIF
+// orientation, the frame is first normalized into a new full-frame buffer
+// (data is left unchanged), which may change the returned width and
+// height (e.g. for the transpose cases).
+func OptimizeFrame(data []byte, width, height int, opts FrameOptions) ([]byte, int, int) {
+	if opts.Orientation > 1 {
+		data, width, height = NormalizeOrientation(data, width, height, opts.Orientation)
+	}
+
+	return optimizeFrameTiled(data, width, height, opts), width, height
 }
 
-// ApplyDithering applies Floyd-Steinberg dithering for better color depth
-func ApplyDithering(data []byte, width, height int) []byte {
-	output := make([]byte, len(data))
-	copy(output, data)
-	
-	for y := 0; y < height-1; y++ {
-		for x := 1; x < width-1; x++ {
-			idx := (y*width + x) * 4
-			if idx+3 >= len(output) {
-				continue
-			}
-			
-			oldR := int(output[idx])
-			oldG := int(output[idx+1])
-			oldB := int(output[idx+2])
-			
-			// Quantize to 8 levels
-			newR := (oldR / 32) * 32
-			newG := (oldG / 32) * 32
-			newB := (oldB / 32) * 32
-			
-			output[idx] = byte(newR)
-			output[idx+1] = byte(newG)
-			output[idx+2] = byte(newB)
-			
-			// Distribute error
-			errR := oldR - newR
-			errG := oldG - newG
-			errB := oldB - newB
-			
-			// Right pixel
-			if idx+4 < len(output) {
-				output[idx+4] = clamp(int(output[idx+4]) + errR*7/16)
-				output[idx+5] = clamp(int(output[idx+5]) + errG*7/16)
-				output[idx+6] = clamp(int(output[idx+6]) + errB*7/16)
-			}
-			
-			// Bottom-left pixel
-			if idx+width*4-4 >= 0 && idx+width*4-4 < len(output) {
-				output[idx+width*4-4] = clamp(int(output[idx+width*4-4]) + errR*3/16)
-				output[idx+width*4-3] = clamp(int(output[idx+width*4-3]) + errG*3/16)
-				output[idx+width*4-2] = clamp(int(output[idx+width*4-2]) + errB*3/16)
-			}
-			
-			// Bottom pixel
-			if idx+width*4 < len(output) {
-				output[idx+width*4] = clamp(int(output[idx+width*4]) + errR*5/16)
-				output[idx+width*4+1] = clamp(int(output[idx+width*4+1]) + errG*5/16)
-				output[idx+width*4+2] = clamp(int(output[idx+width*4+2]) + errB*5/16)
-			}
-			
-			// Bottom-right pixel
-			if idx+width*4+4 < len(output) {
-				output[idx+width*4+4] = clamp(int(output[idx+width*4+4]) + errR*1/16)
-				output[idx+width*4+5] = clamp(int(output[idx+width*4+5]) + errG*1/16)
-				output[idx+width*4+6] = clamp(int(output[idx+width*4+6]) + errB*1/16)
-			}
+// gammaCorrect applies the gamma transform selected by mode.
+func gammaCorrect(v byte, mode GammaMode) byte {
+	switch mode {
+	case GammaSRGB:
+		return linearToSrgb8[v]
+	case GammaPure22:
+		val := math.Pow(float64(v)/255.0, 1/2.2) * 255.0
+		if val > 255 {
+			return 255
 		}
+		return byte(val)
+	case GammaLinear:
+		val := float64(v) * 1.8
+		if val > 255 {
+			return 255
+		}
+		return byte(val)
+	default: // GammaNone
+		return v
 	}
-	
-	return output
 }
 
 func clamp(v int) byte {
@@ -125,4 +120,3 @@ func clamp(v int) byte {
 	}
 	return byte(v)
 }
-
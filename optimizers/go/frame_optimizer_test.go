@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+// TestGammaSRGBEncodesNotNoop guards against gammaCorrect(GammaSRGB, ...)
+// composing the encode LUT with its own inverse (or applying the wrong
+// direction), either of which would leave the output ~equal to the input
+// instead of applying a real sRGB correction.
+func TestGammaSRGBEncodesNotNoop(t *testing.T) {
+	for _, v := range []byte{10, 50, 100, 128, 200, 255} {
+		got := gammaCorrect(v, GammaSRGB)
+		want := linearToSrgb8[v]
+		if got != want {
+			t.Errorf("gammaCorrect(%d, GammaSRGB) = %d, want %d (linearToSrgb8[v])", v, got, want)
+		}
+	}
+	// Shadows should be pulled noticeably brighter by a real sRGB encode,
+	// not left ~unchanged.
+	if got := gammaCorrect(50, GammaSRGB); got <= 50 {
+		t.Errorf("gammaCorrect(50, GammaSRGB) = %d, want a value well above 50", got)
+	}
+}
+
+// TestSrgbLutsMonotonic checks both LUTs are non-decreasing, which should
+// hold for any correctly built transfer-function table.
+func TestSrgbLutsMonotonic(t *testing.T) {
+	for i := 1; i < 256; i++ {
+		if srgbToLinear8[i] < srgbToLinear8[i-1] {
+			t.Fatalf("srgbToLinear8 not monotonic at %d: %d < %d", i, srgbToLinear8[i], srgbToLinear8[i-1])
+		}
+		if linearToSrgb8[i] < linearToSrgb8[i-1] {
+			t.Fatalf("linearToSrgb8 not monotonic at %d: %d < %d", i, linearToSrgb8[i], linearToSrgb8[i-1])
+		}
+	}
+}
+
+// TestSrgbLutsEndpoints checks both LUTs map the transfer function's fixed
+// points (0 and 1) correctly, in each direction.
+func TestSrgbLutsEndpoints(t *testing.T) {
+	if srgbToLinear8[0] != 0 || srgbToLinear8[255] != 255 {
+		t.Errorf("srgbToLinear8 endpoints = [%d, %d], want [0, 255]", srgbToLinear8[0], srgbToLinear8[255])
+	}
+	if linearToSrgb8[0] != 0 || linearToSrgb8[255] != 255 {
+		t.Errorf("linearToSrgb8 endpoints = [%d, %d], want [0, 255]", linearToSrgb8[0], linearToSrgb8[255])
+	}
+}
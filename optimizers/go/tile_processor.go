@@ -0,0 +1,323 @@
+package main
+
+import (
+	"image/color"
+	"runtime"
+	"sync"
+)
+
+// TileOp processes one tile, reading from tile.Data (which includes the
+// halo) and writing the tile's core result into tile.Out.
+type TileOp func(tile *Tile) error
+
+// Tile is one TileW x TileH (plus halo) region of a larger frame.
+type Tile struct {
+	// X, Y, W, H describe the tile's core region in full-frame coordinates.
+	X, Y, W, H int
+	// Halo is how far Data extends beyond the core on each side (clipped
+	// at the frame edges).
+	Halo int
+
+	// Data is the haloed RGBA sub-buffer, row-major with stride DataW*4.
+	Data         []byte
+	DataW, DataH int
+	// OriginX, OriginY is where Data[0] maps to in full-frame coordinates.
+	OriginX, OriginY int
+
+	// Out is the W*H*4 RGBA buffer the op must fill with the tile's result.
+	Out []byte
+}
+
+// At returns the 4-byte RGBA pixel at full-frame coordinates (x, y), which
+// may be anywhere within the tile's halo.
+func (t *Tile) At(x, y int) []byte {
+	lx, ly := x-t.OriginX, y-t.OriginY
+	idx := (ly*t.DataW + lx) * 4
+	return t.Data[idx : idx+4]
+}
+
+// SetOut writes a pixel into the tile's output buffer, addressed relative
+// to the tile's core origin (0..W-1, 0..H-1).
+func (t *Tile) SetOut(x, y int, px []byte) {
+	idx := (y*t.W + x) * 4
+	copy(t.Out[idx:idx+4], px)
+}
+
+// TileProcessor walks a frame in TileW x TileH tiles with a Halo-sized
+// overlap, so filters needing neighbor pixels (convolution, dithering) can
+// run tile-by-tile. With a zero halo, Run writes results back into the
+// input buffer and allocates no second full-frame buffer, which keeps
+// memory down when a WASM instance is working against a 4K or 8K canvas
+// with tight linear memory. With a nonzero halo, Run allocates a
+// full-frame output buffer, and ApplyDithererTiled always works on a
+// full-frame copy.
+type TileProcessor struct {
+	TileW, TileH int
+	Halo         int
+}
+
+// NewTileProcessor builds a processor for the given tile size and halo.
+func NewTileProcessor(tileW, tileH, halo int) *TileProcessor {
+	return &TileProcessor{TileW: tileW, TileH: tileH, Halo: halo}
+}
+
+// workerCount bounds the tile worker pool: runtime.NumCPU() off the main
+// goroutine normally, or 1 under GOOS=js, which is single-threaded unless
+// the caller is bridging out to Web Workers itself.
+func workerCount() int {
+	if runtime.GOOS == "js" {
+		return 1
+	}
+	n := runtime.NumCPU()
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// Run walks data in tiles, invoking op on each with a bounded worker pool,
+// and returns the stitched result. Tiles write disjoint core regions, so
+// this is safe to run concurrently even though neighboring tiles' halos
+// overlap in their (read-only) input.
+//
+// When tp.Halo is 0, no tile ever reads a pixel outside its own core, so
+// Run writes results straight back into data instead of allocating a second
+// full-frame buffer: the returned slice is data itself. With a nonzero
+// halo, tiles do read into neighboring tiles' cores, so a separate output
+// buffer is allocated to avoid a tile clobbering input another tile still
+// needs to extract.
+func (tp *TileProcessor) Run(data []byte, w, h int, op TileOp) ([]byte, error) {
+	output := data
+	if tp.Halo != 0 {
+		output = make([]byte, len(data))
+	}
+
+	type job struct{ tx, ty int }
+	cols := (w + tp.TileW - 1) / tp.TileW
+	rows := (h + tp.TileH - 1) / tp.TileH
+
+	jobs := make(chan job, cols*rows)
+	for ty := 0; ty < rows; ty++ {
+		for tx := 0; tx < cols; tx++ {
+			jobs <- job{tx, ty}
+		}
+	}
+	close(jobs)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	workers := workerCount()
+	if workers > cols*rows {
+		workers = cols * rows
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				tile := tp.extractTile(data, w, h, j.tx, j.ty)
+				if err := op(tile); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					return
+				}
+				tp.writeTile(output, w, h, tile)
+			}
+		}()
+	}
+
+	wg.Wait()
+	return output, firstErr
+}
+
+func (tp *TileProcessor) extractTile(data []byte, w, h, tx, ty int) *Tile {
+	x := tx * tp.TileW
+	y := ty * tp.TileH
+	tw := tp.TileW
+	th := tp.TileH
+	if x+tw > w {
+		tw = w - x
+	}
+	if y+th > h {
+		th = h - y
+	}
+
+	originX := x - tp.Halo
+	originY := y - tp.Halo
+	if originX < 0 {
+		originX = 0
+	}
+	if originY < 0 {
+		originY = 0
+	}
+	endX := x + tw + tp.Halo
+	endY := y + th + tp.Halo
+	if endX > w {
+		endX = w
+	}
+	if endY > h {
+		endY = h
+	}
+
+	dataW := endX - originX
+	dataH := endY - originY
+	buf := make([]byte, dataW*dataH*4)
+	for row := 0; row < dataH; row++ {
+		srcStart := ((originY+row)*w + originX) * 4
+		copy(buf[row*dataW*4:(row+1)*dataW*4], data[srcStart:srcStart+dataW*4])
+	}
+
+	return &Tile{
+		X: x, Y: y, W: tw, H: th, Halo: tp.Halo,
+		Data: buf, DataW: dataW, DataH: dataH,
+		OriginX: originX, OriginY: originY,
+		Out: make([]byte, tw*th*4),
+	}
+}
+
+func (tp *TileProcessor) writeTile(output []byte, w, h int, tile *Tile) {
+	for row := 0; row < tile.H; row++ {
+		dstStart := ((tile.Y+row)*w + tile.X) * 4
+		srcStart := row * tile.W * 4
+		copy(output[dstStart:dstStart+tile.W*4], tile.Out[srcStart:srcStart+tile.W*4])
+	}
+}
+
+// optimizeFrameTiled reimplements OptimizeFrame's gamma pass on top of
+// TileProcessor. Gamma correction needs no neighbor pixels, so it runs with
+// zero halo and Run writes the result back into data in place, without ever
+// allocating a second full-frame buffer.
+func optimizeFrameTiled(data []byte, width, height int, opts FrameOptions) []byte {
+	tp := NewTileProcessor(256, 256, 0)
+	output, _ := tp.Run(data, width, height, func(tile *Tile) error {
+		for y := 0; y < tile.H; y++ {
+			for x := 0; x < tile.W; x++ {
+				px := tile.At(tile.X+x, tile.Y+y)
+				out := [4]byte{
+					gammaCorrect(px[0], opts.Gamma),
+					gammaCorrect(px[1], opts.Gamma),
+					gammaCorrect(px[2], opts.Gamma),
+					px[3],
+				}
+				tile.SetOut(x, y, out[:])
+			}
+		}
+		return nil
+	})
+	return output
+}
+
+// ApplyDithererTiled runs a Ditherer over the frame in TileW x TileH tiles
+// using a serpentine tile order (left-to-right, then right-to-left on the
+// next tile row) with a shared row-boundary error buffer, so diffusion
+// dithering still converges correctly across tile seams instead of
+// producing visible banding at tile edges. Unlike Run, this must be
+// single-threaded: each tile's diffused error feeds the next tile in scan
+// order.
+func (tp *TileProcessor) ApplyDithererTiled(data []byte, width, height int, d Ditherer) []byte {
+	output := make([]byte, len(data))
+	copy(output, data)
+
+	cols := (width + tp.TileW - 1) / tp.TileW
+	rows := (height + tp.TileH - 1) / tp.TileH
+
+	for ty := 0; ty < rows; ty++ {
+		leftToRight := ty%2 == 0
+		for c := 0; c < cols; c++ {
+			tx := c
+			if !leftToRight {
+				tx = cols - 1 - c
+			}
+			ditherTileInPlace(output, width, height, tx, ty, tp.TileW, tp.TileH, d, leftToRight)
+		}
+	}
+
+	return output
+}
+
+// ditherTileInPlace quantizes and diffuses error for one tile directly in
+// the shared output buffer, so error carries across tile (and row)
+// boundaries the same way it would in a single full-frame pass.
+func ditherTileInPlace(output []byte, width, height, tx, ty, tileW, tileH int, d Ditherer, leftToRight bool) {
+	x0 := tx * tileW
+	y0 := ty * tileH
+	x1 := x0 + tileW
+	y1 := y0 + tileH
+	if x1 > width {
+		x1 = width
+	}
+	if y1 > height {
+		y1 = height
+	}
+
+	for y := y0; y < y1; y++ {
+		xs := makeRange(x0, x1, leftToRight)
+		for _, x := range xs {
+			idx := (y*width + x) * 4
+			if idx+3 >= len(output) {
+				continue
+			}
+
+			before := rgbaAt(output, idx)
+
+			var after rgbaPixel
+			if bd, ok := d.(BayerDitherer); ok {
+				after = rgbaFromColor(bd.QuantizeAt(before.toColor(), x, y))
+			} else {
+				after = rgbaFromColor(d.Quantize(before.toColor()))
+			}
+
+			output[idx] = after.r
+			output[idx+1] = after.g
+			output[idx+2] = after.b
+
+			diffErr := [3]int{
+				int(before.r) - int(after.r),
+				int(before.g) - int(after.g),
+				int(before.b) - int(after.b),
+			}
+			d.Diffuse(diffErr, x, y, output, width, height)
+		}
+	}
+}
+
+func makeRange(lo, hi int, ascending bool) []int {
+	n := hi - lo
+	if n < 0 {
+		n = 0
+	}
+	out := make([]int, n)
+	for i := 0; i < n; i++ {
+		if ascending {
+			out[i] = lo + i
+		} else {
+			out[i] = hi - 1 - i
+		}
+	}
+	return out
+}
+
+type rgbaPixel struct{ r, g, b, a byte }
+
+func rgbaAt(buf []byte, idx int) rgbaPixel {
+	return rgbaPixel{r: buf[idx], g: buf[idx+1], b: buf[idx+2], a: buf[idx+3]}
+}
+
+func (p rgbaPixel) toColor() color.RGBA {
+	return color.RGBA{R: p.r, G: p.g, B: p.b, A: p.a}
+}
+
+func rgbaFromColor(c color.RGBA) rgbaPixel {
+	return rgbaPixel{r: c.R, g: c.G, b: c.B, a: c.A}
+}
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+// TestRunZeroHaloWritesInPlace guards against Run reintroducing a full
+// second frame buffer for the zero-halo case: the whole point of
+// TileProcessor is to avoid holding two full-size buffers at once when a
+// tile never reads outside its own core.
+func TestRunZeroHaloWritesInPlace(t *testing.T) {
+	w, h := 4, 4
+	data := make([]byte, w*h*4)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	tp := NewTileProcessor(2, 2, 0)
+	out, err := tp.Run(data, w, h, func(tile *Tile) error {
+		for y := 0; y < tile.H; y++ {
+			for x := 0; x < tile.W; x++ {
+				px := tile.At(tile.X+x, tile.Y+y)
+				tile.SetOut(x, y, []byte{px[0], px[1], px[2], px[3]})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if &out[0] != &data[0] {
+		t.Fatalf("Run with zero halo allocated a new buffer instead of writing back into data")
+	}
+}
+
+// TestApplyDithererMatchesTiled checks ApplyDitherer is wired to the tiled
+// serpentine implementation rather than a separate, untested scanline path.
+func TestApplyDithererMatchesTiled(t *testing.T) {
+	w, h := 6, 6
+	data := make([]byte, w*h*4)
+	for i := 0; i < w*h; i++ {
+		data[i*4] = byte(i * 7 % 255)
+		data[i*4+1] = byte(i * 13 % 255)
+		data[i*4+2] = byte(i * 19 % 255)
+		data[i*4+3] = 255
+	}
+
+	d := FloydSteinbergDitherer{}
+	got := ApplyDitherer(data, w, h, d)
+	want := NewTileProcessor(256, 256, 0).ApplyDithererTiled(data, w, h, d)
+
+	if len(got) != len(want) {
+		t.Fatalf("length mismatch: got %d, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("byte %d: got %d, want %d", i, got[i], want[i])
+		}
+	}
+}